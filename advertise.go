@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MrE-Fog/serve/mdns"
+)
+
+var (
+	mdnsEnabled = flag.Bool("mdns", false, "advertise this server's hostname on the LAN via mDNS/Bonjour")
+	mdnsName    = flag.String("mdns-name", "", "hostname to advertise via mDNS, without \".local\" (default: the system hostname)")
+)
+
+// startMDNS starts an mdns.Responder advertising "<name>.local" for port, if --mdns was passed. It
+// returns a nil Responder and nil error if mDNS wasn't requested, since defaultSANs() already
+// fabricates the "<hostname>.local" names this just makes resolvable on the LAN. The responder
+// discovers which address to answer with per interface itself, via the netdiscover package.
+func startMDNS(port int) (*mdns.Responder, error) {
+	if !*mdnsEnabled {
+		return nil, nil
+	}
+
+	name := *mdnsName
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("mdns: determine hostname: %w", err)
+		}
+		name = hostname
+	}
+
+	responder := mdns.New(name+".local", port)
+	if err := responder.Start(); err != nil {
+		return nil, err
+	}
+	log.Printf("mdns: advertising %s.local on port %d", name, port)
+	return responder, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+)
+
+// CertManager holds a self-signed TLS certificate covering a set of SANs, generated entirely in
+// memory, and can reissue it whenever the network changes the set of addresses the server is
+// reachable at (see WatchSANs).
+type CertManager struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertManager creates a CertManager with a certificate covering sans.
+func NewCertManager(sans []string) (*CertManager, error) {
+	m := &CertManager{}
+	if err := m.Reissue(sans); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reissue generates a new self-signed certificate covering sans and atomically swaps it in;
+// in-flight handshakes keep using whichever certificate GetCertificate already handed them.
+func (m *CertManager) Reissue(sans []string) error {
+	cert, err := generateSelfSigned(sans)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate: it always returns the most recently issued
+// certificate, regardless of the SNI name requested, since this is a single self-signed cert
+// covering every SAN we know about.
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return nil, fmt.Errorf("certs: no certificate issued yet")
+	}
+	return m.cert, nil
+}
+
+// generateSelfSigned creates a self-signed ECDSA certificate covering sans, which may be a mix of
+// DNS names and IP addresses.
+func generateSelfSigned(sans []string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("certs: generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("certs: generate serial: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "serve (self-signed)"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+			continue
+		}
+		template.DNSNames = append(template.DNSNames, san)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("certs: create certificate: %w", err)
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
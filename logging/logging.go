@@ -0,0 +1,204 @@
+// Package logging provides a structured access-log subsystem: a handful of well-known line
+// formats (plain, Common Log Format, Combined Log Format, JSON) written to one or more sinks
+// (stderr, rotating file, syslog).
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Format selects how a Record is rendered to a line of text.
+type Format int
+
+const (
+	// Plain is this server's historical one-line log.Printf format.
+	Plain Format = iota
+	// CLF is the Common Log Format used by Apache/NCSA.
+	CLF
+	// Combined is CLF plus the Referer and User-Agent fields.
+	Combined
+	// JSON renders one JSON object per line.
+	JSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case Plain:
+		return "plain"
+	case CLF:
+		return "clf"
+	case Combined:
+		return "combined"
+	case JSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// Record holds everything an access log line might want to report about one request.
+type Record struct {
+	Start      time.Time
+	Duration   time.Duration
+	RemoteAddr string
+	Method     string
+	URI        string
+	Proto      string
+	Status     int
+	Bytes      int
+	Referer    string
+	UserAgent  string
+
+	// TLSVersion and TLSCipher are empty for plaintext requests.
+	TLSVersion string
+	TLSCipher  string
+	// HTTP2StreamID is 0 unless the caller has threaded one through via context, since net/http
+	// doesn't expose it directly.
+	HTTP2StreamID uint32
+
+	// RequestBodyBytes is how many bytes were read from the request body, which may be less than
+	// Content-Length if the handler didn't read the whole thing.
+	RequestBodyBytes int64
+	// Route is the matched route/handler name, if the caller set one.
+	Route string
+	// RequestID is the request ID recoverHandler assigns and echoes as X-Request-ID, so a
+	// user-reported failure can be traced back to the exact access-log line. recoverHandler also
+	// writes it into its panic log entry, but withTracing writes no access-log line for a request
+	// whose handler panics.
+	RequestID string
+}
+
+// Formatter renders a Record as a single log line, without a trailing newline.
+type Formatter func(Record) []byte
+
+// FormatterFor returns the Formatter for f, defaulting to PlainFormatter for unknown values.
+func FormatterFor(f Format) Formatter {
+	switch f {
+	case CLF:
+		return CLFFormatter
+	case Combined:
+		return CombinedFormatter
+	case JSON:
+		return JSONFormatter
+	default:
+		return PlainFormatter
+	}
+}
+
+// PlainFormatter reproduces this server's original log.Printf-based line.
+func PlainFormatter(r Record) []byte {
+	return []byte(fmt.Sprintf("%s [%s] %q %s %d %d %q",
+		r.RemoteAddr, r.Method, r.URI, r.Proto, r.Status, r.Bytes, r.UserAgent))
+}
+
+// CLFFormatter renders r in the Common Log Format, with the request ID appended as a trailing
+// quoted field so a line can still be correlated with a panic log entry.
+func CLFFormatter(r Record) []byte {
+	return []byte(fmt.Sprintf("%s - - [%s] %q %d %d %q",
+		remoteHost(r.RemoteAddr), r.Start.Format("02/Jan/2006:15:04:05 -0700"),
+		fmt.Sprintf("%s %s %s", r.Method, r.URI, r.Proto), r.Status, r.Bytes, r.RequestID))
+}
+
+// CombinedFormatter renders r in the Combined Log Format: CLF plus Referer and User-Agent.
+func CombinedFormatter(r Record) []byte {
+	var buf bytes.Buffer
+	buf.Write(CLFFormatter(r))
+	fmt.Fprintf(&buf, " %q %q", r.Referer, r.UserAgent)
+	return buf.Bytes()
+}
+
+// JSONFormatter renders r as a single-line JSON object.
+func JSONFormatter(r Record) []byte {
+	rec := struct {
+		Timestamp  string `json:"ts"`
+		Remote     string `json:"remote"`
+		Method     string `json:"method"`
+		URI        string `json:"uri"`
+		Proto      string `json:"proto"`
+		Status     int    `json:"status"`
+		RespBytes  int    `json:"resp_bytes"`
+		ReqBytes   int64  `json:"req_bytes"`
+		DurationMS int64  `json:"duration_ms"`
+		UserAgent  string `json:"ua,omitempty"`
+		Referer    string `json:"referer,omitempty"`
+		TLS        string `json:"tls,omitempty"`
+		StreamID   uint32 `json:"http2_stream_id,omitempty"`
+		Route      string `json:"route,omitempty"`
+		RequestID  string `json:"request_id,omitempty"`
+	}{
+		Timestamp:  r.Start.UTC().Format(time.RFC3339Nano),
+		Remote:     r.RemoteAddr,
+		Method:     r.Method,
+		URI:        r.URI,
+		Proto:      r.Proto,
+		Status:     r.Status,
+		RespBytes:  r.Bytes,
+		ReqBytes:   r.RequestBodyBytes,
+		DurationMS: r.Duration.Milliseconds(),
+		UserAgent:  r.UserAgent,
+		Referer:    r.Referer,
+		TLS:        tlsField(r.TLSVersion, r.TLSCipher),
+		StreamID:   r.HTTP2StreamID,
+		Route:      r.Route,
+		RequestID:  r.RequestID,
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		// Should be unreachable since Record only has marshalable fields, but a log line must
+		// never be allowed to panic the request it's describing.
+		return []byte(fmt.Sprintf(`{"error":"logging: marshal record: %s"}`, err))
+	}
+	return b
+}
+
+// tlsField combines version and cipher into the single "tls" field the JSON record uses, empty
+// for plaintext requests.
+func tlsField(version, cipher string) string {
+	if version == "" {
+		return ""
+	}
+	if cipher == "" {
+		return version
+	}
+	return version + "/" + cipher
+}
+
+func remoteHost(remoteAddr string) string {
+	for i := len(remoteAddr) - 1; i >= 0; i-- {
+		if remoteAddr[i] == ':' {
+			return remoteAddr[:i]
+		}
+	}
+	return remoteAddr
+}
+
+// Sink writes a rendered log line somewhere: stderr, a file, syslog, ...
+type Sink interface {
+	Write(line []byte) error
+}
+
+// Logger formats Records with a Formatter and fans the result out to one or more Sinks.
+type Logger struct {
+	format Formatter
+	sinks  []Sink
+}
+
+// New creates a Logger that renders with format and writes to sinks.
+func New(format Format, sinks ...Sink) *Logger {
+	return &Logger{format: FormatterFor(format), sinks: sinks}
+}
+
+// Log renders r and writes it to every configured sink. A sink error is logged via the standard
+// logger and otherwise ignored: a broken access-log sink must not take down request handling.
+func (l *Logger) Log(r Record) {
+	line := l.format(r)
+	for _, sink := range l.sinks {
+		if err := sink.Write(line); err != nil {
+			log.Printf("logging: sink write failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecord() Record {
+	return Record{
+		Start:            time.Date(2026, 7, 26, 12, 0, 0, 0, time.UTC),
+		Duration:         42 * time.Millisecond,
+		RemoteAddr:       "192.168.1.5:54321",
+		Method:           "GET",
+		URI:              "/index.html",
+		Proto:            "HTTP/1.1",
+		Status:           200,
+		Bytes:            1234,
+		Referer:          "https://example.com/",
+		UserAgent:        "test-agent/1.0",
+		TLSVersion:       "TLS1.3",
+		TLSCipher:        "TLS_AES_128_GCM_SHA256",
+		RequestBodyBytes: 56,
+		Route:            "static",
+		RequestID:        "req-abc123",
+	}
+}
+
+func TestCLFFormatter(t *testing.T) {
+	line := string(CLFFormatter(testRecord()))
+	if !strings.HasPrefix(line, "192.168.1.5 - - [26/Jul/2026:12:00:00 +0000] ") {
+		t.Errorf("CLFFormatter: unexpected prefix: %s", line)
+	}
+	if !strings.Contains(line, `"GET /index.html HTTP/1.1"`) {
+		t.Errorf("CLFFormatter: missing request line: %s", line)
+	}
+	if !strings.Contains(line, "200 1234") {
+		t.Errorf("CLFFormatter: missing status/bytes: %s", line)
+	}
+	if !strings.HasSuffix(line, `"req-abc123"`) {
+		t.Errorf("CLFFormatter: missing request ID suffix: %s", line)
+	}
+	if strings.Contains(line, "test-agent") {
+		t.Errorf("CLFFormatter: should not include user-agent: %s", line)
+	}
+}
+
+func TestCombinedFormatterAddsRefererAndUserAgent(t *testing.T) {
+	line := string(CombinedFormatter(testRecord()))
+	if !strings.HasSuffix(line, `"https://example.com/" "test-agent/1.0"`) {
+		t.Errorf("CombinedFormatter: missing referer/user-agent suffix: %s", line)
+	}
+	if !strings.Contains(line, `"req-abc123"`) {
+		t.Errorf("CombinedFormatter: missing request ID: %s", line)
+	}
+}
+
+func TestJSONFormatterFieldNames(t *testing.T) {
+	b := JSONFormatter(testRecord())
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatalf("JSONFormatter output doesn't parse as JSON: %v", err)
+	}
+
+	wantPresent := []string{"ts", "remote", "method", "uri", "proto", "status", "resp_bytes", "req_bytes", "duration_ms", "ua", "referer", "tls", "request_id"}
+	for _, key := range wantPresent {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("JSONFormatter output missing field %q: %s", key, b)
+		}
+	}
+
+	wantAbsent := []string{"bytes", "request_body_bytes", "user_agent", "tls_version", "tls_cipher"}
+	for _, key := range wantAbsent {
+		if _, ok := fields[key]; ok {
+			t.Errorf("JSONFormatter output has stale field %q: %s", key, b)
+		}
+	}
+
+	if got := fields["resp_bytes"]; got != float64(1234) {
+		t.Errorf("resp_bytes = %v, want 1234", got)
+	}
+	if got := fields["req_bytes"]; got != float64(56) {
+		t.Errorf("req_bytes = %v, want 56", got)
+	}
+	if got := fields["tls"]; got != "TLS1.3/TLS_AES_128_GCM_SHA256" {
+		t.Errorf("tls = %v, want TLS1.3/TLS_AES_128_GCM_SHA256", got)
+	}
+	if got := fields["request_id"]; got != "req-abc123" {
+		t.Errorf("request_id = %v, want req-abc123", got)
+	}
+}
+
+func TestJSONFormatterOmitsTLSForPlaintext(t *testing.T) {
+	r := testRecord()
+	r.TLSVersion = ""
+	r.TLSCipher = ""
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(JSONFormatter(r), &fields); err != nil {
+		t.Fatalf("JSONFormatter output doesn't parse as JSON: %v", err)
+	}
+	if _, ok := fields["tls"]; ok {
+		t.Errorf("JSONFormatter: tls field present for plaintext request: %v", fields["tls"])
+	}
+}
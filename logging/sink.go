@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// writerSink writes every line to w, followed by a newline, under a mutex since the underlying
+// io.Writer (e.g. os.Stderr) isn't necessarily safe for concurrent writes.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStderrSink returns a Sink that writes to os.Stderr.
+func NewStderrSink() Sink {
+	return &writerSink{w: os.Stderr}
+}
+
+func (s *writerSink) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return err
+	}
+	_, err := s.w.Write([]byte{'\n'})
+	return err
+}
+
+// FileSink writes lines to a file, rotating it once it exceeds maxSize bytes or maxAge since it
+// was opened, whichever comes first. A maxSize or maxAge of 0 disables that trigger.
+type FileSink struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// NewFileSink opens (or creates) path for appending and returns a FileSink that rotates it by
+// size and/or age. Rotated files are renamed to "<path>.<RFC3339 timestamp>".
+func NewFileSink(path string, maxSize int64, maxAge time.Duration) (*FileSink, error) {
+	s := &FileSink{path: path, maxSize: maxSize, maxAge: maxAge}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("logging: open %s: %w", s.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logging: stat %s: %w", s.path, err)
+	}
+	s.f = f
+	s.size = info.Size()
+	s.opened = time.Now()
+	return nil
+}
+
+func (s *FileSink) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shouldRotate(int64(len(line) + 1)) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.f.Write(append(append([]byte{}, line...), '\n'))
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) shouldRotate(nextWrite int64) bool {
+	if s.maxSize > 0 && s.size+nextWrite > s.maxSize {
+		return true
+	}
+	if s.maxAge > 0 && time.Since(s.opened) > s.maxAge {
+		return true
+	}
+	return false
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("logging: close %s for rotation: %w", s.path, err)
+	}
+	rotated := fmt.Sprintf("%s.%s", s.path, time.Now().UTC().Format(time.RFC3339))
+	if err := os.Rename(s.path, rotated); err != nil {
+		return fmt.Errorf("logging: rotate %s: %w", s.path, err)
+	}
+	return s.open()
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
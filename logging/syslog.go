@@ -0,0 +1,28 @@
+//go:build !windows
+
+package logging
+
+import "log/syslog"
+
+// SyslogSink writes lines to the local syslog daemon under the given tag.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink dials the local syslog daemon and returns a Sink that writes to it, tagged as tag.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+func (s *SyslogSink) Write(line []byte) error {
+	return s.w.Info(string(line))
+}
+
+// Close closes the underlying syslog connection.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package logging
+
+import "errors"
+
+// errSyslogUnsupported is returned by NewSyslogSink on Windows, which has no syslog daemon.
+var errSyslogUnsupported = errors.New("logging: syslog sink is not supported on windows")
+
+// SyslogSink is unusable on Windows; NewSyslogSink always returns errSyslogUnsupported.
+type SyslogSink struct{}
+
+// NewSyslogSink always fails on Windows. It exists so callers can build a Sink list without
+// platform-specific code.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	return nil, errSyslogUnsupported
+}
+
+func (s *SyslogSink) Write(line []byte) error {
+	return errSyslogUnsupported
+}
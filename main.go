@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/MrE-Fog/serve/logging"
+)
+
+var (
+	addr = flag.String("addr", ":8443", "address to listen on")
+	dir  = flag.String("dir", ".", "directory to serve")
+
+	logFormat     = flag.String("log-format", "plain", "access log format: plain, clf, combined, or json")
+	logFile       = flag.String("log-file", "", "write access logs to this file instead of stderr")
+	logRotateSize = flag.Int64("log-rotate-size", 0, "rotate --log-file once it exceeds this many bytes (0 disables size-based rotation)")
+)
+
+func main() {
+	flag.Parse()
+
+	accessLogger, err := newAccessLogger(*logFormat, *logFile, *logRotateSize)
+	if err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+	SetAccessLogger(accessLogger)
+
+	sans := defaultSANs()
+	certManager, err := NewCertManager(sans)
+	if err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+
+	mon, err := WatchSANs(func(sans []string) {
+		if err := certManager.Reissue(sans); err != nil {
+			log.Printf("serve: reissue certificate: %v", err)
+			return
+		}
+		log.Printf("serve: reissued certificate for %v", sans)
+	})
+	if err != nil {
+		log.Printf("serve: netmon unavailable, certificate won't follow network changes: %v", err)
+	} else {
+		defer mon.Close()
+	}
+
+	if responder, err := startMDNS(portFromAddr(*addr)); err != nil {
+		log.Printf("serve: mdns: %v", err)
+	} else if responder != nil {
+		defer responder.Close()
+	}
+
+	handler := recoverHandler(withTracing(http.FileServer(http.Dir(*dir))))
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			GetCertificate: certManager.GetCertificate,
+		},
+	}
+
+	log.Printf("serve: serving %s on https://%s", *dir, *addr)
+	log.Fatal(server.ListenAndServeTLS("", ""))
+}
+
+// portFromAddr extracts the numeric port from a "host:port" listen address, defaulting to 443 if
+// addr doesn't parse or its port is a non-numeric service name (e.g. ":https").
+func portFromAddr(addr string) int {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 443
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 443
+	}
+	return port
+}
+
+// newAccessLogger builds the access logger described by --log-format/--log-file/--log-rotate-size.
+// format must be one of "plain", "clf", "combined", or "json". If file is empty, logs go to
+// stderr; otherwise they're appended to file, rotating once it exceeds rotateSize bytes (0
+// disables size-based rotation).
+func newAccessLogger(format, file string, rotateSize int64) (*logging.Logger, error) {
+	f, err := parseLogFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if file == "" {
+		return logging.New(f, logging.NewStderrSink()), nil
+	}
+
+	sink, err := logging.NewFileSink(file, rotateSize, 0)
+	if err != nil {
+		return nil, fmt.Errorf("serve: %w", err)
+	}
+	return logging.New(f, sink), nil
+}
+
+func parseLogFormat(s string) (logging.Format, error) {
+	switch s {
+	case "plain":
+		return logging.Plain, nil
+	case "clf":
+		return logging.CLF, nil
+	case "combined":
+		return logging.Combined, nil
+	case "json":
+		return logging.JSON, nil
+	default:
+		return 0, fmt.Errorf("serve: unknown --log-format %q (want plain, clf, combined, or json)", s)
+	}
+}
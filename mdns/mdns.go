@@ -0,0 +1,184 @@
+// Package mdns implements a minimal mDNS/DNS-SD responder (RFC 6762/6763): it advertises a
+// hostname for A/AAAA lookups on the LAN and publishes an "_https._tcp" PTR/SRV/TXT record
+// pointing at it, the way Bonjour/Avahi discovery expects.
+package mdns
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/MrE-Fog/serve/netdiscover"
+)
+
+const mdnsPort = 5353
+
+var (
+	ipv4Group = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+	ipv6Group = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: mdnsPort}
+
+	// defaultTTL is the TTL mDNS convention uses for records tied to a host that's still present
+	// on the network; see RFC 6762 section 10.
+	defaultTTL uint32 = 120
+
+	errNoMulticastInterfaces = errors.New("mdns: no multicast-capable interfaces found")
+)
+
+// serviceName is the DNS-SD service type this package advertises under.
+const serviceName = "_https._tcp.local."
+
+// connAddrs is the LAN addresses reachable on the interface a given multicast socket was opened
+// on, so a query can be answered with the address of the link it actually arrived on instead of a
+// single host-wide guess.
+type connAddrs struct {
+	v4, v6 net.IP
+}
+
+// Responder answers mDNS queries for one hostname and advertises it as an "_https._tcp" service.
+type Responder struct {
+	host string // e.g. "myhost.local."
+	port int
+	txt  []string
+
+	mu      sync.RWMutex
+	conns   []*net.UDPConn
+	perConn map[*net.UDPConn]connAddrs
+}
+
+// New creates a Responder that will advertise host (e.g. "myhost.local") as serving HTTPS on
+// port, with optional "key=value" TXT records. Call Start to begin answering queries; the
+// addresses it answers with are discovered per-interface via the netdiscover package.
+func New(host string, port int, txt ...string) *Responder {
+	if !strings.HasSuffix(host, ".") {
+		host += "."
+	}
+	return &Responder{host: host, port: port, txt: txt, perConn: make(map[*net.UDPConn]connAddrs)}
+}
+
+// Start joins the mDNS multicast groups (224.0.0.251 and ff02::fb, UDP 5353) on every
+// multicast-capable interface netdiscover reports an address for, and begins answering queries in
+// the background, each on the interface the query arrived on.
+func (r *Responder) Start() error {
+	ifaceAddrs, err := netdiscover.AllReachableIPs()
+	if err != nil {
+		return err
+	}
+
+	for _, ia := range ifaceAddrs {
+		iface := ia.Interface
+		if iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+
+		var addrs connAddrs
+		if ia.IPv4.IsValid() {
+			addrs.v4 = net.IP(ia.IPv4.AsSlice())
+		}
+		if ia.IPv6.IsValid() {
+			addrs.v6 = net.IP(ia.IPv6.AsSlice())
+		}
+		if addrs.v4 == nil && addrs.v6 == nil {
+			continue
+		}
+
+		if conn, err := net.ListenMulticastUDP("udp4", &iface, ipv4Group); err == nil {
+			r.addConn(conn, addrs)
+			go r.serve(conn)
+		}
+		if conn, err := net.ListenMulticastUDP("udp6", &iface, ipv6Group); err == nil {
+			r.addConn(conn, addrs)
+			go r.serve(conn)
+		}
+	}
+
+	if len(r.conns) == 0 {
+		return errNoMulticastInterfaces
+	}
+	return nil
+}
+
+func (r *Responder) addConn(conn *net.UDPConn, addrs connAddrs) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conns = append(r.conns, conn)
+	r.perConn[conn] = addrs
+}
+
+// Close stops answering queries and leaves the multicast groups.
+func (r *Responder) Close() error {
+	var firstErr error
+	for _, conn := range r.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *Responder) serve(conn *net.UDPConn) {
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		r.handleQuery(conn, buf[:n])
+	}
+}
+
+func (r *Responder) handleQuery(conn *net.UDPConn, packet []byte) {
+	// DNS names are case-insensitive, and os.Hostname() (the default --mdns-name) is frequently
+	// mixed-case, so names must be compared case-insensitively rather than byte-for-byte.
+	for _, q := range parseQueryNames(packet) {
+		switch {
+		case strings.EqualFold(q.name, r.host) && (q.qtype == typeA || q.qtype == typeAAAA || q.qtype == typeANY):
+			r.respondHost(conn)
+		case strings.EqualFold(q.name, serviceName) && (q.qtype == typePTR || q.qtype == typeANY):
+			r.respondService(conn)
+		}
+	}
+}
+
+func (r *Responder) respondHost(conn *net.UDPConn) {
+	r.mu.RLock()
+	addrs := r.perConn[conn]
+	r.mu.RUnlock()
+
+	var rrs []resourceRecord
+	if addrs.v4 != nil {
+		var a [4]byte
+		copy(a[:], addrs.v4.To4())
+		rrs = append(rrs, aRecord(r.host, a, defaultTTL))
+	}
+	if addrs.v6 != nil {
+		var a [16]byte
+		copy(a[:], addrs.v6.To16())
+		rrs = append(rrs, aaaaRecord(r.host, a, defaultTTL))
+	}
+	if len(rrs) == 0 {
+		return
+	}
+	r.sendMulticast(conn, rrs)
+}
+
+func (r *Responder) respondService(conn *net.UDPConn) {
+	instance := strings.TrimSuffix(r.host, ".") + "." + serviceName
+	rrs := []resourceRecord{
+		ptrRecord(serviceName, instance, defaultTTL),
+		srvRecord(instance, r.host, uint16(r.port), defaultTTL),
+		txtRecord(instance, r.txt, defaultTTL),
+	}
+	r.sendMulticast(conn, rrs)
+}
+
+func (r *Responder) sendMulticast(conn *net.UDPConn, rrs []resourceRecord) {
+	group := ipv4Group
+	if localAddr, ok := conn.LocalAddr().(*net.UDPAddr); ok && localAddr.IP.To4() == nil {
+		group = ipv6Group
+	}
+	if _, err := conn.WriteToUDP(buildResponse(rrs), group); err != nil {
+		log.Printf("mdns: write response: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// DNS record types and class used by mDNS (RFC 6762/6763). classINFlushBit marks an answer as
+// replacing any previously cached record for that name, as mDNS responses should.
+const (
+	classIN         = 1
+	classINFlushBit = 0x8000
+
+	typeA    = 1
+	typePTR  = 12
+	typeTXT  = 16
+	typeAAAA = 28
+	typeSRV  = 33
+	typeANY  = 255
+)
+
+// question is a parsed DNS question; it's all we need out of an incoming mDNS query.
+type question struct {
+	name  string
+	qtype uint16
+}
+
+// parseQueryNames extracts the question names/types from a raw mDNS query packet. It returns as
+// many questions as it managed to parse rather than failing the whole packet over a trailing
+// section (known-answer suppression records, additional records, ...) we don't need to read.
+func parseQueryNames(buf []byte) []question {
+	if len(buf) < 12 {
+		return nil
+	}
+	qdcount := binary.BigEndian.Uint16(buf[4:6])
+	off := 12
+
+	var out []question
+	for i := 0; i < int(qdcount); i++ {
+		name, next, ok := readName(buf, off)
+		if !ok || next+4 > len(buf) {
+			break
+		}
+		qtype := binary.BigEndian.Uint16(buf[next : next+2])
+		out = append(out, question{name: name, qtype: qtype})
+		off = next + 4 // skip qtype + qclass
+	}
+	return out
+}
+
+// readName reads a (possibly compressed) DNS name starting at off and returns it in dotted form
+// plus the offset just past it in the original buffer.
+func readName(buf []byte, off int) (string, int, bool) {
+	var labels []string
+	afterFirst := -1
+	guard := 0
+	for {
+		guard++
+		if guard > 128 || off >= len(buf) {
+			return "", 0, false
+		}
+		length := int(buf[off])
+		if length == 0 {
+			off++
+			break
+		}
+		if length&0xc0 == 0xc0 { // compression pointer
+			if off+1 >= len(buf) {
+				return "", 0, false
+			}
+			ptr := (length&0x3f)<<8 | int(buf[off+1])
+			if afterFirst == -1 {
+				afterFirst = off + 2
+			}
+			off = ptr
+			continue
+		}
+		off++
+		if off+length > len(buf) {
+			return "", 0, false
+		}
+		labels = append(labels, string(buf[off:off+length]))
+		off += length
+	}
+	if afterFirst != -1 {
+		off = afterFirst
+	}
+	return strings.Join(labels, ".") + ".", off, true
+}
+
+func encodeName(name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	var out []byte
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			continue
+		}
+		out = append(out, byte(len(label)))
+		out = append(out, label...)
+	}
+	return append(out, 0)
+}
+
+// resourceRecord is one answer in an mDNS response.
+type resourceRecord struct {
+	name  string
+	rtype uint16
+	ttl   uint32
+	data  []byte
+	// shared marks a record as belonging to a shared resource-record set (RFC 6762 §10.2), such
+	// as a DNS-SD PTR enumerating a service type that other hosts also answer for. Shared records
+	// must not set the cache-flush bit, since that tells listeners to discard every other cached
+	// record for that name, including ones owned by other hosts.
+	shared bool
+}
+
+func (rr resourceRecord) encode() []byte {
+	buf := encodeName(rr.name)
+
+	class := uint16(classIN)
+	if !rr.shared {
+		class |= classINFlushBit
+	}
+	typeClass := make([]byte, 4)
+	binary.BigEndian.PutUint16(typeClass[0:2], rr.rtype)
+	binary.BigEndian.PutUint16(typeClass[2:4], class)
+	buf = append(buf, typeClass...)
+
+	ttlLen := make([]byte, 6)
+	binary.BigEndian.PutUint32(ttlLen[0:4], rr.ttl)
+	binary.BigEndian.PutUint16(ttlLen[4:6], uint16(len(rr.data)))
+	buf = append(buf, ttlLen...)
+
+	return append(buf, rr.data...)
+}
+
+// buildResponse assembles an mDNS response packet (header + answers) from rrs.
+func buildResponse(rrs []resourceRecord) []byte {
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint16(header[2:4], 0x8400) // QR=1 (response), AA=1 (authoritative)
+	binary.BigEndian.PutUint16(header[6:8], uint16(len(rrs)))
+
+	buf := header
+	for _, rr := range rrs {
+		buf = append(buf, rr.encode()...)
+	}
+	return buf
+}
+
+func aRecord(name string, ip [4]byte, ttl uint32) resourceRecord {
+	return resourceRecord{name: name, rtype: typeA, ttl: ttl, data: ip[:]}
+}
+
+func aaaaRecord(name string, ip [16]byte, ttl uint32) resourceRecord {
+	return resourceRecord{name: name, rtype: typeAAAA, ttl: ttl, data: ip[:]}
+}
+
+func srvRecord(name, target string, port uint16, ttl uint32) resourceRecord {
+	data := make([]byte, 6) // priority=0, weight=0
+	binary.BigEndian.PutUint16(data[4:6], port)
+	data = append(data, encodeName(target)...)
+	return resourceRecord{name: name, rtype: typeSRV, ttl: ttl, data: data}
+}
+
+func ptrRecord(name, target string, ttl uint32) resourceRecord {
+	// DNS-SD service-type PTRs are a shared record set: every host advertising "_https._tcp"
+	// answers with one, so this must not carry the cache-flush bit.
+	return resourceRecord{name: name, rtype: typePTR, ttl: ttl, data: encodeName(target), shared: true}
+}
+
+func txtRecord(name string, pairs []string, ttl uint32) resourceRecord {
+	if len(pairs) == 0 {
+		pairs = []string{""}
+	}
+	var data []byte
+	for _, p := range pairs {
+		if len(p) > 255 {
+			p = p[:255]
+		}
+		data = append(data, byte(len(p)))
+		data = append(data, p...)
+	}
+	return resourceRecord{name: name, rtype: typeTXT, ttl: ttl, data: data}
+}
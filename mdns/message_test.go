@@ -0,0 +1,100 @@
+package mdns
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeReadNameRoundTrip(t *testing.T) {
+	cases := []string{
+		"myhost.local.",
+		"myhost.local",
+		"_https._tcp.local.",
+		"a.b.c.",
+	}
+	for _, name := range cases {
+		encoded := encodeName(name)
+		got, next, ok := readName(encoded, 0)
+		if !ok {
+			t.Fatalf("readName(%q): ok = false", name)
+		}
+		if next != len(encoded) {
+			t.Errorf("readName(%q): consumed %d bytes, want %d", name, next, len(encoded))
+		}
+		want := name
+		if want[len(want)-1] != '.' {
+			want += "."
+		}
+		if got != want {
+			t.Errorf("readName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestReadNameCompressionPointer(t *testing.T) {
+	// "myhost.local." at offset 0, followed by a second name that's just a compression pointer
+	// back to it, as a real mDNS packet would encode a repeated name.
+	first := encodeName("myhost.local.")
+	buf := append(append([]byte{}, first...), 0xc0, 0x00)
+
+	got, next, ok := readName(buf, len(first))
+	if !ok {
+		t.Fatalf("readName: ok = false")
+	}
+	if got != "myhost.local." {
+		t.Errorf("readName via pointer = %q, want %q", got, "myhost.local.")
+	}
+	if want := len(first) + 2; next != want {
+		t.Errorf("readName via pointer consumed %d bytes, want %d", next, want)
+	}
+}
+
+func TestResourceRecordEncodeUniqueSetsFlushBit(t *testing.T) {
+	rr := aRecord("myhost.local.", [4]byte{192, 168, 1, 5}, 120)
+	enc := rr.encode()
+
+	name, off, ok := readName(enc, 0)
+	if !ok || name != "myhost.local." {
+		t.Fatalf("readName(encoded) = %q, %v, want myhost.local.", name, ok)
+	}
+
+	class := uint16(enc[off+2])<<8 | uint16(enc[off+3])
+	if class&classINFlushBit == 0 {
+		t.Errorf("unique record: cache-flush bit not set in class %#x", class)
+	}
+	if class&^classINFlushBit != classIN {
+		t.Errorf("class without flush bit = %#x, want %#x", class&^classINFlushBit, classIN)
+	}
+
+	ip := enc[off+10 : off+14]
+	if !bytes.Equal(ip, []byte{192, 168, 1, 5}) {
+		t.Errorf("A record data = %v, want 192.168.1.5", ip)
+	}
+}
+
+func TestResourceRecordEncodeSharedOmitsFlushBit(t *testing.T) {
+	rr := ptrRecord(serviceName, "myhost._https._tcp.local.", 120)
+	enc := rr.encode()
+
+	_, off, ok := readName(enc, 0)
+	if !ok {
+		t.Fatalf("readName(encoded): ok = false")
+	}
+
+	class := uint16(enc[off+2])<<8 | uint16(enc[off+3])
+	if class&classINFlushBit != 0 {
+		t.Errorf("shared record: cache-flush bit set, want unset")
+	}
+}
+
+func TestTxtRecordTruncatesLongValues(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	rr := txtRecord("myhost._https._tcp.local.", []string{string(long)}, 120)
+
+	if got := int(rr.data[0]); got != 255 {
+		t.Errorf("txt record length byte = %d, want 255", got)
+	}
+	if got := len(rr.data) - 1; got != 255 {
+		t.Errorf("txt record value length = %d, want 255", got)
+	}
+}
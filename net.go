@@ -1,60 +1,24 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
 	"log"
-	"net"
+	"net/http"
 	"os"
-	"runtime"
+	"runtime/debug"
 	"strings"
-)
-
-// getAddressesFromIface goes through the addresses of the given interface and tries to return the first of each kind.
-//
-// The interesting interfaces like eth0 and wlan0 typically have 2 addresses: one IPv4 and one IPv6 address.
-// But some interfaces just have one of them, or if an interface is deactivated it doesn't have any.
-// On Windows the main network interface like "Ethernet 3" can have many addresses and the main IPv4 address doesn't have to be one of the first 2.
-// We must take care of all these combinations.
-func getAddressesFromIface(iface net.Interface) (ipv4 string, ipv6 string) {
-	addrs, err := iface.Addrs()
-	if err != nil {
-		log.Fatal(err)
-	}
-	for i := 0; i < len(addrs) && (ipv4 == "" || ipv6 == ""); i++ {
-		// In the case of two addresses they could potentially be of the same type.
-		// We want to show the first address. overwriteIfEmpty() doesn't overwrite existing values.
-		addrWithoutMask := strings.Split(addrs[i].String(), "/")[0]
-		if strings.Contains(addrWithoutMask, ":") {
-			overwriteIfEmpty(&ipv4, "")
-			overwriteIfEmpty(&ipv6, addrWithoutMask)
-		} else {
-			overwriteIfEmpty(&ipv4, addrWithoutMask)
-			overwriteIfEmpty(&ipv6, "")
-		}
-	}
-	return
-}
+	"time"
 
-// isFav checks the network interface's name and if it's a typical main one (like "eth0" on Linux) it returns true.
-//
-// Note: All possible runtime.GOOS values are listed here: https://golang.org/doc/install/source#environment
-func isFav(iface net.Interface) bool {
-	switch runtime.GOOS {
-	case "windows":
-		if iface.Name == "WiFi" ||
-			len(iface.Name) >= 8 && iface.Name[:8] == "Ethernet" {
-			return true
-		}
-	case "darwin":
-		if iface.Name == "en0" || iface.Name == "en1" {
-			return true
-		}
-	case "linux":
-		if iface.Name == "eth0" || iface.Name == "wlan0" {
-			return true
-		}
-	}
-	return false
-}
+	"github.com/MrE-Fog/serve/logging"
+	"github.com/MrE-Fog/serve/netdiscover"
+	"github.com/MrE-Fog/serve/netmon"
+)
 
 // defaultSANs returns DNS names and IP addresses that might be used to reach the current host,
 // either from the host itself or from other machines in the local network.
@@ -66,28 +30,56 @@ func defaultSANs() []string {
 		result = append(result, hostname, hostname+".local", "*."+hostname+".local", hostname+".lan", "*."+hostname+".lan", hostname+".home", "*."+hostname+".home")
 	}
 
-	lanIP, err := lanIP()
+	v4, v6, err := netdiscover.PrimaryIPs()
 	if err == nil {
-		result = append(result, lanIP)
+		if v4.IsValid() {
+			result = append(result, v4.String())
+		}
+		if v6.IsValid() {
+			result = append(result, v6.String())
+		}
 	}
 
 	return result
 }
 
-// lanIP tries to determine the IP address of the current machine in the LAN.
-func lanIP() (string, error) {
-	ifaces, err := net.Interfaces()
+// WatchSANs starts a background netmon.Monitor and calls onChange with the new set of SANs
+// whenever the set returned by defaultSANs() changes, for example so an HTTPS server can reissue
+// its certificate after a network change (new Wi-Fi network, cable unplugged, VPN connected).
+//
+// The returned Monitor keeps watching until its Close method is called.
+func WatchSANs(onChange func([]string)) (*netmon.Monitor, error) {
+	mon, err := netmon.New()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	last := defaultSANs()
+	mon.Subscribe(func(netmon.Delta) {
+		cur := defaultSANs()
+		if sameSANs(last, cur) {
+			return
+		}
+		last = cur
+		onChange(cur)
+	})
+
+	if err := mon.Start(); err != nil {
+		return nil, err
+	}
+	return mon, nil
+}
+
+func sameSANs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
 	}
-	fav := ""
-	for _, iface := range ifaces {
-		if isFav(iface) {
-			fav, _ = getAddressesFromIface(iface)
-			break
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
-	return fav, nil
+	return true
 }
 
 type statusWriter struct {
@@ -110,21 +102,211 @@ func (w *statusWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// countingReadCloser wraps a request body to count the bytes actually read from it, which may be
+// less than Content-Length if the handler doesn't read the whole body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (c countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+type routeNameKey struct{}
+
+// WithRouteName returns a copy of r whose context records name as the matched route/handler name,
+// to be picked up by the access logger installed with SetAccessLogger.
+func WithRouteName(r *http.Request, name string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeNameKey{}, name))
+}
+
+func routeNameFrom(r *http.Request) string {
+	name, _ := r.Context().Value(routeNameKey{}).(string)
+	return name
+}
+
+type http2StreamIDKey struct{}
+
+// WithHTTP2StreamID returns a copy of r whose context records streamID, to be picked up by the
+// access logger. net/http doesn't expose the HTTP/2 stream ID itself, so callers with access to it
+// (e.g. through a custom http2.Transport/Server hook) must set it explicitly.
+func WithHTTP2StreamID(r *http.Request, streamID uint32) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), http2StreamIDKey{}, streamID))
+}
+
+func http2StreamIDFrom(r *http.Request) uint32 {
+	id, _ := r.Context().Value(http2StreamIDKey{}).(uint32)
+	return id
+}
+
+// accessLogger is the structured access logger used by withTracing. It defaults to nil, in which
+// case withTracing falls back to the plain log.Printf line this server has always written.
+var accessLogger *logging.Logger
+
+// SetAccessLogger installs l as the structured access logger used by withTracing. Passing nil
+// restores the default plain-text logging.
+func SetAccessLogger(l *logging.Logger) {
+	accessLogger = l
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	default:
+		return "unknown"
+	}
+}
+
 func withTracing(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		var bodyBytes int64
+		if r.Body != nil {
+			r.Body = countingReadCloser{ReadCloser: r.Body, n: &bodyBytes}
+		}
+
 		response := &statusWriter{ResponseWriter: w}
 		next.ServeHTTP(response, r)
-		defer log.Printf("%s [%s] %q %s %d %d %q", r.RemoteAddr, r.Method, r.RequestURI, r.Proto, response.status, response.length, r.Header.Get("User-Agent"))
+
+		if accessLogger == nil {
+			log.Printf("%s [%s] %q %s %d %d %q", r.RemoteAddr, r.Method, r.RequestURI, r.Proto, response.status, response.length, r.Header.Get("User-Agent"))
+			return
+		}
+
+		rec := logging.Record{
+			Start:            start,
+			Duration:         time.Since(start),
+			RemoteAddr:       r.RemoteAddr,
+			Method:           r.Method,
+			URI:              r.RequestURI,
+			Proto:            r.Proto,
+			Status:           response.status,
+			Bytes:            response.length,
+			Referer:          r.Referer(),
+			UserAgent:        r.Header.Get("User-Agent"),
+			HTTP2StreamID:    http2StreamIDFrom(r),
+			RequestBodyBytes: bodyBytes,
+			Route:            routeNameFrom(r),
+			RequestID:        RequestIDFrom(r),
+		}
+		if r.TLS != nil {
+			rec.TLSVersion = tlsVersionName(r.TLS.Version)
+			rec.TLSCipher = tls.CipherSuiteName(r.TLS.CipherSuite)
+		}
+		accessLogger.Log(rec)
 	}
 }
 
+type requestIDKey struct{}
+
+// OnPanic, if non-nil, is called by recoverHandler with the panic value and stack trace whenever
+// it recovers from a panic, before the error response is written. It's meant for hooking up
+// alerting/metrics and must not itself panic; recoverHandler recovers from it but drops it.
+var OnPanic func(r *http.Request, v interface{}, stack []byte)
+
+// WithRequestID returns a copy of r whose context carries id as its request ID.
+func WithRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// RequestIDFrom returns the request ID stored in r's context, or "" if none was set.
+func RequestIDFrom(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random RFC 4122 version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// isSafeRequestID reports whether an inbound X-Request-ID header value is safe to adopt and echo
+// straight back: bounded length and restricted to characters that can't break header parsing or
+// be mistaken for something else downstream (e.g. in a log line).
+func isSafeRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.StatusText}}</title></head>
+<body>
+<h1>{{.StatusText}}</h1>
+<p>Something went wrong handling your request. If it keeps happening, mention request ID <code>{{.RequestID}}</code> when you report it.</p>
+</body>
+</html>
+`))
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, requestID string) {
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		errorPageTemplate.Execute(w, struct {
+			StatusText string
+			RequestID  string
+		}{http.StatusText(http.StatusInternalServerError), requestID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}{http.StatusText(http.StatusInternalServerError), requestID})
+}
+
 func recoverHandler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !isSafeRequestID(id) {
+			id = newRequestID()
+		}
+		r = WithRequestID(r, id)
+		w.Header().Set("X-Request-ID", id)
+
 		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("panic: %+v", err)
-				http.Error(w, http.StatusText(500), 500)
+			v := recover()
+			if v == nil {
+				return
 			}
+
+			stack := debug.Stack()
+			log.Printf("panic: %+v\nrequest_id=%s\n%s", v, id, stack)
+
+			if OnPanic != nil {
+				func() {
+					defer func() { recover() }()
+					OnPanic(r, v, stack)
+				}()
+			}
+
+			writeErrorResponse(w, r, id)
 		}()
 
 		next.ServeHTTP(w, r)
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSafeRequestID(t *testing.T) {
+	cases := []struct {
+		id   string
+		want bool
+	}{
+		{"", false},
+		{"abc-123_XYZ", true},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+		{"has a space", false},
+		{"has/a/slash", false},
+		{"has\nnewline", false},
+	}
+	for _, c := range cases {
+		if got := isSafeRequestID(c.id); got != c.want {
+			t.Errorf("isSafeRequestID(%q) = %v, want %v", c.id, got, c.want)
+		}
+	}
+}
+
+func TestNewRequestIDIsUnique(t *testing.T) {
+	a := newRequestID()
+	b := newRequestID()
+	if a == b {
+		t.Fatalf("newRequestID returned the same ID twice: %q", a)
+	}
+	if !isSafeRequestID(a) || !isSafeRequestID(b) {
+		t.Errorf("newRequestID produced an ID isSafeRequestID rejects: %q, %q", a, b)
+	}
+}
+
+func TestNewRequestIDFormat(t *testing.T) {
+	id := newRequestID()
+	parts := strings.Split(id, "-")
+	if len(parts) != 5 {
+		t.Fatalf("newRequestID() = %q, want 5 hyphen-separated groups", id)
+	}
+	lengths := []int{8, 4, 4, 4, 12}
+	for i, part := range parts {
+		if len(part) != lengths[i] {
+			t.Errorf("newRequestID() group %d = %q, want length %d", i, part, lengths[i])
+		}
+	}
+	if parts[2][0] != '4' {
+		t.Errorf("newRequestID() version nibble = %q, want 4", parts[2][:1])
+	}
+}
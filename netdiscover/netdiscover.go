@@ -0,0 +1,248 @@
+// Package netdiscover figures out which of the host's network interfaces and addresses are
+// actually reachable from the LAN, replacing one-shot, name-based heuristics like "eth0 is
+// probably the right interface" with interface scoring and a default-route probe.
+package netdiscover
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"runtime"
+	"strings"
+)
+
+// ErrNoInterface is returned when no interface looks suitable to reach the LAN from.
+var ErrNoInterface = errors.New("netdiscover: no suitable network interface found")
+
+// PrimaryInterface returns the network interface most likely to be used to reach the LAN and the
+// internet, chosen by scoreInterface with favInterfaceNames as a last-resort tie-breaker.
+func PrimaryInterface() (net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return net.Interface{}, err
+	}
+
+	var best net.Interface
+	bestScore := -1
+	found := false
+	for _, iface := range ifaces {
+		score := scoreInterface(iface)
+		if score <= bestScore {
+			continue
+		}
+		best, bestScore, found = iface, score, true
+	}
+	if !found {
+		return net.Interface{}, ErrNoInterface
+	}
+	return best, nil
+}
+
+// PrimaryIPs returns the IPv4 and/or IPv6 address the current machine is reachable at in the LAN.
+// Either return value may be the zero netip.Addr if no address of that family was found; err is
+// only set if neither address could be determined at all.
+func PrimaryIPs() (v4, v6 netip.Addr, err error) {
+	if ip, ok := defaultRouteIP("udp4", "8.8.8.8:80"); ok {
+		v4 = ip
+	}
+	if ip, ok := defaultRouteIP("udp6", "[2001:4860:4860::8888]:80"); ok {
+		v6 = ip
+	}
+	if v4.IsValid() && v6.IsValid() {
+		return v4, v6, nil
+	}
+
+	iface, ifaceErr := PrimaryInterface()
+	if ifaceErr == nil {
+		addrs, _ := interfaceAddrs(iface)
+		if !v4.IsValid() {
+			v4 = addrs.v4
+		}
+		if !v6.IsValid() {
+			v6 = addrs.v6
+		}
+	}
+
+	if !v4.IsValid() && !v6.IsValid() {
+		if ifaceErr != nil {
+			return v4, v6, ifaceErr
+		}
+		return v4, v6, ErrNoInterface
+	}
+	return v4, v6, nil
+}
+
+// InterfaceAddrs is one up, non-loopback interface and the LAN addresses reachable on it.
+type InterfaceAddrs struct {
+	Interface net.Interface
+	IPv4      netip.Addr
+	IPv6      netip.Addr
+}
+
+// AllReachableIPs returns, for every up, non-loopback interface that has one, the IPv4 and/or
+// IPv6 address reachable on it. Interfaces whose names match excludedInterfacePrefixes (container
+// bridges, VPN tunnels, ...) are left out. Advertising subsystems (like mDNS) should use this rather than a
+// single host-wide address so they can answer each query on the interface it actually arrived on.
+func AllReachableIPs() ([]InterfaceAddrs, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []InterfaceAddrs
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if isExcludedInterfaceName(iface.Name) {
+			continue
+		}
+		addrs, err := interfaceAddrs(iface)
+		if err != nil || (!addrs.v4.IsValid() && !addrs.v6.IsValid()) {
+			continue
+		}
+		out = append(out, InterfaceAddrs{Interface: iface, IPv4: addrs.v4, IPv6: addrs.v6})
+	}
+	return out, nil
+}
+
+type addrPair struct {
+	v4, v6 netip.Addr
+}
+
+// interfaceAddrs returns the first IPv4 and first IPv6 address configured on iface.
+func interfaceAddrs(iface net.Interface) (addrPair, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return addrPair{}, err
+	}
+
+	var out addrPair
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		if addr.Is4() {
+			if !out.v4.IsValid() {
+				out.v4 = addr
+			}
+		} else if !out.v6.IsValid() {
+			out.v6 = addr
+		}
+	}
+	return out, nil
+}
+
+// defaultRouteIP asks the kernel which local address it would use to reach addr over network,
+// without actually sending any traffic: it "dials" a UDP socket and reads back LocalAddr(). This
+// mirrors what UDP-dial tricks in tools like Tailscale and Kubernetes' util/net do to find the
+// default-route source address, and works offline since no packet is ever sent.
+func defaultRouteIP(network, addr string) (netip.Addr, bool) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	defer conn.Close()
+
+	udpAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	ip, ok := netip.AddrFromSlice(udpAddr.IP)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+	if ip.IsUnspecified() {
+		return netip.Addr{}, false
+	}
+	return ip, true
+}
+
+// excludedInterfacePrefixes lists name prefixes for interfaces that are never the one we want to
+// pick or advertise an address for: container/VM bridges and virtual Ethernet pairs (docker, br-,
+// virbr, veth), and VPN/tunnel interfaces (utun, tun, tap, tailscale, zt for ZeroTier) - all of
+// these otherwise look like perfectly good, "up" interfaces with an address.
+var excludedInterfacePrefixes = []string{
+	"docker", "veth", "br-", "virbr",
+	"utun", "tun", "tap", "tailscale", "zt",
+}
+
+// isExcludedInterfaceName reports whether name belongs to one of excludedInterfacePrefixes, used
+// both by scoreInterface (to rank such interfaces last) and AllReachableIPs (to skip them
+// entirely, since nothing should advertise mDNS answers on a docker bridge or VPN tunnel).
+func isExcludedInterfaceName(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range excludedInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// favInterfaceNames lists interface names that are typically the main one on each OS, used only
+// as a last-resort tie-breaker by scoreInterface.
+//
+// Note: All possible runtime.GOOS values are listed here: https://golang.org/doc/install/source#environment
+func favInterfaceNames() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"WiFi", "Ethernet"}
+	case "darwin":
+		return []string{"en0", "en1"}
+	case "linux":
+		return []string{"eth0", "wlan0"}
+	}
+	return nil
+}
+
+// scoreInterface ranks iface by how likely it is to be the one actually used to reach the LAN.
+// Higher is better; a negative score means the interface should never be picked.
+func scoreInterface(iface net.Interface) int {
+	score := scoreInterfaceFlags(iface)
+	if score < 0 {
+		return score
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil || len(addrs) == 0 {
+		return -1
+	}
+	return score + 1
+}
+
+// scoreInterfaceFlags computes the flag- and name-based portion of scoreInterface's score,
+// without querying iface's configured addresses, so it can be unit tested with plain
+// net.Interface{Flags: ..., Name: ...} values instead of real, registered interfaces.
+func scoreInterfaceFlags(iface net.Interface) int {
+	if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 {
+		return -1
+	}
+	if iface.Flags&net.FlagLoopback != 0 {
+		return -1
+	}
+
+	score := 0
+	if iface.Flags&net.FlagPointToPoint != 0 {
+		// VPNs and other tunnel interfaces are usually not what we want to advertise.
+		score -= 5
+	}
+	if isExcludedInterfaceName(iface.Name) {
+		score -= 10
+	}
+	for _, fav := range favInterfaceNames() {
+		if iface.Name == fav || strings.HasPrefix(iface.Name, fav) {
+			score += 3
+			break
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,58 @@
+package netdiscover
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsExcludedInterfaceName(t *testing.T) {
+	excluded := []string{"docker0", "veth1234", "br-abcdef", "virbr0", "utun0", "tun0", "tap0", "tailscale0", "zt7890", "ZT7890"}
+	for _, name := range excluded {
+		if !isExcludedInterfaceName(name) {
+			t.Errorf("isExcludedInterfaceName(%q) = false, want true", name)
+		}
+	}
+
+	allowed := []string{"eth0", "wlan0", "en0", "WiFi", "Ethernet", ""}
+	for _, name := range allowed {
+		if isExcludedInterfaceName(name) {
+			t.Errorf("isExcludedInterfaceName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestScoreInterfaceFlagsDown(t *testing.T) {
+	iface := net.Interface{Name: "eth0", Flags: 0}
+	if got := scoreInterfaceFlags(iface); got >= 0 {
+		t.Errorf("scoreInterfaceFlags(down) = %d, want negative", got)
+	}
+}
+
+func TestScoreInterfaceFlagsLoopback(t *testing.T) {
+	iface := net.Interface{Name: "lo", Flags: net.FlagUp | net.FlagRunning | net.FlagLoopback}
+	if got := scoreInterfaceFlags(iface); got >= 0 {
+		t.Errorf("scoreInterfaceFlags(loopback) = %d, want negative", got)
+	}
+}
+
+func TestScoreInterfaceFlagsExcludedNameScoresBelowPlainInterface(t *testing.T) {
+	plain := net.Interface{Name: "eth1", Flags: net.FlagUp | net.FlagRunning}
+	docker := net.Interface{Name: "docker0", Flags: net.FlagUp | net.FlagRunning}
+	tailscale := net.Interface{Name: "tailscale0", Flags: net.FlagUp | net.FlagRunning}
+
+	plainScore := scoreInterfaceFlags(plain)
+	if got := scoreInterfaceFlags(docker); got >= plainScore {
+		t.Errorf("scoreInterfaceFlags(docker0) = %d, want less than plain interface score %d", got, plainScore)
+	}
+	if got := scoreInterfaceFlags(tailscale); got >= plainScore {
+		t.Errorf("scoreInterfaceFlags(tailscale0) = %d, want less than plain interface score %d", got, plainScore)
+	}
+}
+
+func TestScoreInterfaceFlagsPointToPointPenalized(t *testing.T) {
+	plain := net.Interface{Name: "eth1", Flags: net.FlagUp | net.FlagRunning}
+	ptp := net.Interface{Name: "eth1", Flags: net.FlagUp | net.FlagRunning | net.FlagPointToPoint}
+	if got, want := scoreInterfaceFlags(ptp), scoreInterfaceFlags(plain); got >= want {
+		t.Errorf("scoreInterfaceFlags(point-to-point) = %d, want less than %d", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+// Package netmon watches the host's network interfaces for changes and notifies subscribers when
+// one happens.
+//
+// Unlike one-shot calls to net.Interfaces(), a Monitor stays subscribed to OS-level link/address
+// events for as long as it's running, so callers find out about network changes (Wi-Fi roaming, a
+// cable unplugged, a VPN connecting) as they happen instead of only on the next poll. This mirrors
+// the move tools like Tailscale made away from one-shot interface lookups and towards a
+// subscribing monitor.
+//
+// OS-level events are used on Linux, darwin/BSD and Windows. On every other platform a Monitor
+// re-checks net.Interfaces() every pollInterval instead.
+package netmon
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Delta describes the network interfaces that changed between two snapshots.
+type Delta struct {
+	Added       []net.Interface // interfaces that appeared
+	Removed     []net.Interface // interfaces that disappeared
+	AddrChanged []net.Interface // interfaces whose address set changed
+}
+
+// Empty reports whether the delta carries no actual change.
+func (d Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.AddrChanged) == 0
+}
+
+type ifaceSnapshot struct {
+	iface net.Interface
+	addrs string // joined addrs, used as a cheap change fingerprint
+}
+
+func snapshot() (map[string]ifaceSnapshot, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	snap := make(map[string]ifaceSnapshot, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		key := ""
+		for _, a := range addrs {
+			key += a.String() + ","
+		}
+		snap[iface.Name] = ifaceSnapshot{iface: iface, addrs: key}
+	}
+	return snap, nil
+}
+
+func diff(old, cur map[string]ifaceSnapshot) Delta {
+	var d Delta
+	for name, c := range cur {
+		o, ok := old[name]
+		if !ok {
+			d.Added = append(d.Added, c.iface)
+			continue
+		}
+		if o.addrs != c.addrs {
+			d.AddrChanged = append(d.AddrChanged, c.iface)
+		}
+	}
+	for name, o := range old {
+		if _, ok := cur[name]; !ok {
+			d.Removed = append(d.Removed, o.iface)
+		}
+	}
+	return d
+}
+
+// Monitor watches the host's network interfaces and calls every subscriber whenever one is added,
+// removed, or has its addresses changed.
+type Monitor struct {
+	mu       sync.Mutex
+	subs     []func(Delta)
+	last     map[string]ifaceSnapshot
+	closeFn  func() error
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// New creates a Monitor seeded with the current interface state. Call Start to begin watching.
+func New() (*Monitor, error) {
+	last, err := snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("netmon: initial interface snapshot: %w", err)
+	}
+	return &Monitor{last: last, done: make(chan struct{})}, nil
+}
+
+// Subscribe registers fn to be called with the observed Delta whenever the network interfaces
+// change. fn runs on an internal goroutine and must not block for long.
+func (m *Monitor) Subscribe(fn func(Delta)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs = append(m.subs, fn)
+}
+
+// Start begins watching for network changes in the background and returns once the
+// platform-specific watcher is set up. Watching continues until Close is called.
+func (m *Monitor) Start() error {
+	wake, closeFn, err := newWatcher()
+	if err != nil {
+		return fmt.Errorf("netmon: %w", err)
+	}
+	m.closeFn = closeFn
+	go m.watch(wake)
+	return nil
+}
+
+func (m *Monitor) watch(wake <-chan struct{}) {
+	for {
+		select {
+		case <-m.done:
+			return
+		case _, ok := <-wake:
+			if !ok {
+				return
+			}
+			m.checkNow()
+		}
+	}
+}
+
+func (m *Monitor) checkNow() {
+	cur, err := snapshot()
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	delta := diff(m.last, cur)
+	m.last = cur
+	subs := append([]func(Delta){}, m.subs...)
+	m.mu.Unlock()
+
+	if delta.Empty() {
+		return
+	}
+	for _, fn := range subs {
+		fn(delta)
+	}
+}
+
+// Close stops watching and releases the underlying OS resources.
+func (m *Monitor) Close() error {
+	m.doneOnce.Do(func() { close(m.done) })
+	if m.closeFn != nil {
+		return m.closeFn()
+	}
+	return nil
+}
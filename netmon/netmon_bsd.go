@@ -0,0 +1,33 @@
+//go:build darwin || freebsd || netbsd || openbsd || dragonfly
+
+package netmon
+
+import "syscall"
+
+// newWatcher subscribes to routing socket messages (RTM_IFINFO, RTM_NEWADDR, RTM_DELADDR, ...) on
+// a PF_ROUTE socket, as used on darwin and the BSDs. As on Linux, we don't decode the messages: any
+// message on this socket means "go re-enumerate net.Interfaces() and diff".
+func newWatcher() (<-chan struct{}, func() error, error) {
+	fd, err := syscall.Socket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err != nil || n == 0 {
+				close(wake)
+				return
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return wake, func() error { return syscall.Close(fd) }, nil
+}
@@ -0,0 +1,50 @@
+//go:build linux
+
+package netmon
+
+import "syscall"
+
+// Linux multicast groups for RTNETLINK: link up/down and IPv4/IPv6 address changes.
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4Ifaddr = 0x10
+	rtmgrpIPv6Ifaddr = 0x100
+)
+
+// newWatcher subscribes to RTM_NEWLINK/RTM_DELLINK/RTM_NEWADDR/RTM_DELADDR notifications on a
+// netlink(AF_NETLINK, NETLINK_ROUTE) socket. We don't bother decoding the messages: any message on
+// this socket means "go re-enumerate net.Interfaces() and diff", which is both simpler and more
+// robust than tracking kernel attribute formats.
+func newWatcher() (<-chan struct{}, func() error, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4Ifaddr | rtmgrpIPv6Ifaddr,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, nil, err
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil || n == 0 {
+				close(wake)
+				return
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return wake, func() error { return syscall.Close(fd) }, nil
+}
@@ -0,0 +1,32 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+
+package netmon
+
+import "time"
+
+// pollInterval is how often we fall back to re-checking net.Interfaces() on platforms without a
+// known event source for network changes.
+const pollInterval = 5 * time.Second
+
+func newWatcher() (<-chan struct{}, func() error, error) {
+	wake := make(chan struct{}, 1)
+	done := make(chan struct{})
+
+	ticker := time.NewTicker(pollInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return wake, func() error { close(done); return nil }, nil
+}
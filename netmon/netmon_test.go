@@ -0,0 +1,62 @@
+package netmon
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDeltaEmpty(t *testing.T) {
+	if !(Delta{}).Empty() {
+		t.Errorf("Delta{}.Empty() = false, want true")
+	}
+	if (Delta{Added: []net.Interface{{Name: "eth0"}}}).Empty() {
+		t.Errorf("Delta with Added.Empty() = true, want false")
+	}
+}
+
+func TestDiffAddedAndRemoved(t *testing.T) {
+	old := map[string]ifaceSnapshot{
+		"eth0": {iface: net.Interface{Name: "eth0"}, addrs: "192.168.1.5/24,"},
+	}
+	cur := map[string]ifaceSnapshot{
+		"wlan0": {iface: net.Interface{Name: "wlan0"}, addrs: "192.168.1.6/24,"},
+	}
+
+	d := diff(old, cur)
+	if len(d.Added) != 1 || d.Added[0].Name != "wlan0" {
+		t.Errorf("diff Added = %v, want [wlan0]", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "eth0" {
+		t.Errorf("diff Removed = %v, want [eth0]", d.Removed)
+	}
+	if len(d.AddrChanged) != 0 {
+		t.Errorf("diff AddrChanged = %v, want none", d.AddrChanged)
+	}
+}
+
+func TestDiffAddrChanged(t *testing.T) {
+	old := map[string]ifaceSnapshot{
+		"eth0": {iface: net.Interface{Name: "eth0"}, addrs: "192.168.1.5/24,"},
+	}
+	cur := map[string]ifaceSnapshot{
+		"eth0": {iface: net.Interface{Name: "eth0"}, addrs: "192.168.1.9/24,"},
+	}
+
+	d := diff(old, cur)
+	if len(d.AddrChanged) != 1 || d.AddrChanged[0].Name != "eth0" {
+		t.Errorf("diff AddrChanged = %v, want [eth0]", d.AddrChanged)
+	}
+	if len(d.Added) != 0 || len(d.Removed) != 0 {
+		t.Errorf("diff Added/Removed = %v/%v, want none", d.Added, d.Removed)
+	}
+}
+
+func TestDiffNoChange(t *testing.T) {
+	snap := map[string]ifaceSnapshot{
+		"eth0": {iface: net.Interface{Name: "eth0"}, addrs: "192.168.1.5/24,"},
+	}
+	d := diff(snap, snap)
+	if !d.Empty() {
+		t.Errorf("diff(same, same) = %+v, want empty", d)
+	}
+}
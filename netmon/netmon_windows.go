@@ -0,0 +1,55 @@
+//go:build windows
+
+package netmon
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modiphlpapi                      = syscall.NewLazyDLL("iphlpapi.dll")
+	procNotifyIpInterfaceChange      = modiphlpapi.NewProc("NotifyIpInterfaceChange")
+	procNotifyUnicastIpAddressChange = modiphlpapi.NewProc("NotifyUnicastIpAddressChange")
+	procCancelMibChangeNotify2       = modiphlpapi.NewProc("CancelMibChangeNotify2")
+)
+
+const afUnspec = 0 // AF_UNSPEC: notify for both IPv4 and IPv6
+
+// newWatcher subscribes to NotifyIpInterfaceChange and NotifyUnicastIpAddressChange, the iphlpapi
+// callbacks Windows uses in place of a pollable socket. Both deliver the same kind of event to us
+// (something changed), so the callback just wakes the watch loop, which re-enumerates
+// net.Interfaces() and diffs.
+func newWatcher() (<-chan struct{}, func() error, error) {
+	wake := make(chan struct{}, 1)
+
+	cb := syscall.NewCallback(func(callerContext unsafe.Pointer, row unsafe.Pointer, notificationType uint32) uintptr {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+		return 0
+	})
+
+	var ifaceHandle syscall.Handle
+	if ret, _, _ := procNotifyIpInterfaceChange.Call(
+		uintptr(afUnspec), cb, 0, 0, uintptr(unsafe.Pointer(&ifaceHandle)),
+	); ret != 0 {
+		return nil, nil, syscall.Errno(ret)
+	}
+
+	var addrHandle syscall.Handle
+	if ret, _, _ := procNotifyUnicastIpAddressChange.Call(
+		uintptr(afUnspec), cb, 0, 0, uintptr(unsafe.Pointer(&addrHandle)),
+	); ret != 0 {
+		procCancelMibChangeNotify2.Call(uintptr(ifaceHandle))
+		return nil, nil, syscall.Errno(ret)
+	}
+
+	return wake, func() error {
+		procCancelMibChangeNotify2.Call(uintptr(ifaceHandle))
+		procCancelMibChangeNotify2.Call(uintptr(addrHandle))
+		close(wake)
+		return nil
+	}, nil
+}